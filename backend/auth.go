@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// contextKey avoids collisions with context keys set by other packages.
+type contextKey string
+
+const userIDContextKey contextKey = "user_id"
+
+// User is an account that owns templates and holds API tokens.
+type User struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// generateToken returns a random 64-character hex API token.
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// createUser registers a new user and issues their first API token.
+func createUser(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Email == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	result, err := db.Exec("INSERT INTO users (email) VALUES (?)", input.Email)
+	if err != nil {
+		http.Error(w, "Email might already be registered", http.StatusBadRequest)
+		return
+	}
+	userID, _ := result.LastInsertId()
+
+	token, err := generateToken()
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	if _, err := db.Exec("INSERT INTO tokens (token, user_id) VALUES (?, ?)", token, userID); err != nil {
+		http.Error(w, "Failed to issue token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":    int(userID),
+		"email": input.Email,
+		"token": token,
+	})
+}
+
+// authMiddleware requires a valid "Authorization: Bearer <token>" header
+// and injects the resolved user id into the request context.
+func authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			http.Error(w, "Missing or invalid Authorization header", http.StatusUnauthorized)
+			return
+		}
+		token := strings.TrimPrefix(authHeader, "Bearer ")
+
+		userID, err := resolveUserIDFromToken(token)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIDContextKey, userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// userIDFromContext returns the authenticated user id set by authMiddleware.
+func userIDFromContext(r *http.Request) int {
+	userID, _ := r.Context().Value(userIDContextKey).(int)
+	return userID
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOp is one line of an edit script produced by lcsDiff.
+type diffOp struct {
+	kind byte // ' ' (unchanged), '-' (removed), '+' (added)
+	text string
+}
+
+// lcsDiff computes a line-level edit script between a and b using the
+// classic longest-common-subsequence backtrack.
+func lcsDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff renders a and b as a standard unified diff with three lines
+// of surrounding context, labeling the hunks with fromLabel/toLabel.
+func unifiedDiff(fromLabel, toLabel, a, b string) string {
+	const context = 3
+	aLines := strings.Split(a, "\n")
+	bLines := strings.Split(b, "\n")
+	ops := lcsDiff(aLines, bLines)
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n", fromLabel)
+	fmt.Fprintf(&buf, "+++ %s\n", toLabel)
+
+	type hunk struct {
+		start, end int // indices into ops, [start, end)
+	}
+
+	// Walk the edit script, grouping changes that are within 2*context
+	// lines of each other into a single hunk.
+	var hunks []hunk
+	var cur *hunk
+	gap := 0
+	for idx, op := range ops {
+		if op.kind == ' ' {
+			gap++
+			if cur != nil && gap > 2*context {
+				cur.end = idx - gap + context
+				if cur.end < cur.start {
+					cur.end = cur.start
+				}
+				hunks = append(hunks, *cur)
+				cur = nil
+			}
+		} else {
+			if cur == nil {
+				start := idx - context
+				if start < 0 {
+					start = 0
+				}
+				cur = &hunk{start: start}
+			}
+			gap = 0
+		}
+	}
+	if cur != nil {
+		cur.end = len(ops)
+		hunks = append(hunks, *cur)
+	}
+
+	// Recompute starting line numbers by scanning ops up to each hunk start.
+	for _, h := range hunks {
+		startA, startB := 1, 1
+		for _, op := range ops[:h.start] {
+			switch op.kind {
+			case ' ':
+				startA++
+				startB++
+			case '-':
+				startA++
+			case '+':
+				startB++
+			}
+		}
+		countA, countB := 0, 0
+		for _, op := range ops[h.start:h.end] {
+			switch op.kind {
+			case ' ':
+				countA++
+				countB++
+			case '-':
+				countA++
+			case '+':
+				countB++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", startA, countA, startB, countB)
+		for _, op := range ops[h.start:h.end] {
+			fmt.Fprintf(&buf, "%c%s\n", op.kind, op.text)
+		}
+	}
+
+	return buf.String()
+}
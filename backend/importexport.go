@@ -0,0 +1,494 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// templateBundleItem is the interchange format used by export/import: a
+// single template plus the tag and variable metadata needed to recreate it.
+type templateBundleItem struct {
+	Name      string             `json:"name"`
+	Content   string             `json:"content"`
+	Tags      []string           `json:"tags,omitempty"`
+	Variables []TemplateVariable `json:"variables,omitempty"`
+	CreatedAt time.Time          `json:"created_at,omitempty"`
+}
+
+// importSummary reports what importTemplates did (or, in dry-run mode,
+// would do) for each template in the uploaded bundle.
+type importSummary struct {
+	DryRun    bool     `json:"dry_run"`
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// exportTemplates streams every template visible to the caller as a single
+// bundle in the requested format, one row at a time rather than loading the
+// whole result set into memory first.
+func exportTemplates(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromContext(r)
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "yaml" && format != "zip" {
+		http.Error(w, "Unsupported format (use json, yaml, or zip)", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := db.Query(
+		`SELECT t.id, t.name, t.content, t.template_variables, t.created_at
+		FROM templates t
+		WHERE t.user_id = ? OR t.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = ?)
+		ORDER BY t.name`,
+		userID, userID,
+	)
+	if err != nil {
+		http.Error(w, "Failed to query templates", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	switch format {
+	case "json":
+		exportJSON(w, rows)
+	case "yaml":
+		exportYAML(w, rows)
+	case "zip":
+		exportZIP(w, rows)
+	}
+}
+
+// scanBundleItem reads the current row plus its tags into a bundle item.
+func scanBundleItem(rows *sql.Rows) (templateBundleItem, error) {
+	var item templateBundleItem
+	var id int
+	var variablesJSON string
+	if err := rows.Scan(&id, &item.Name, &item.Content, &variablesJSON, &item.CreatedAt); err != nil {
+		return item, err
+	}
+	if err := json.Unmarshal([]byte(variablesJSON), &item.Variables); err != nil {
+		return item, err
+	}
+	tags, err := templateTags(id)
+	if err != nil {
+		return item, err
+	}
+	item.Tags = tags
+	return item, nil
+}
+
+// exportJSON streams the bundle as a JSON array, encoding one item at a time.
+func exportJSON(w http.ResponseWriter, rows *sql.Rows) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("["))
+	first := true
+	for rows.Next() {
+		item, err := scanBundleItem(rows)
+		if err != nil {
+			log.Println("export json: failed to read template:", err)
+			return
+		}
+		if !first {
+			w.Write([]byte(","))
+		}
+		first = false
+		json.NewEncoder(w).Encode(item)
+	}
+	w.Write([]byte("]"))
+}
+
+// exportYAML streams the bundle as Markdown documents with YAML front
+// matter, back to back, matching how prompt libraries are shared on GitHub.
+func exportYAML(w http.ResponseWriter, rows *sql.Rows) {
+	w.Header().Set("Content-Type", "application/x-yaml")
+	for rows.Next() {
+		item, err := scanBundleItem(rows)
+		if err != nil {
+			log.Println("export yaml: failed to read template:", err)
+			return
+		}
+		io.WriteString(w, buildFrontMatter(item))
+		io.WriteString(w, item.Content)
+		io.WriteString(w, "\n")
+	}
+}
+
+// exportZIP streams the bundle as a ZIP archive with one front-matter
+// Markdown file per template.
+func exportZIP(w http.ResponseWriter, rows *sql.Rows) {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="templates.zip"`)
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+	for rows.Next() {
+		item, err := scanBundleItem(rows)
+		if err != nil {
+			log.Println("export zip: failed to read template:", err)
+			return
+		}
+		f, err := zw.Create(item.Name + ".md")
+		if err != nil {
+			log.Println("export zip: failed to create entry:", err)
+			return
+		}
+		if _, err := io.WriteString(f, buildFrontMatter(item)+item.Content+"\n"); err != nil {
+			log.Println("export zip: failed to write entry:", err)
+			return
+		}
+	}
+}
+
+// buildFrontMatter renders item's metadata as YAML front matter. The
+// structured fields (tags, variables) are written as JSON, which is valid
+// YAML flow syntax, so no YAML encoder is needed to produce or parse it.
+func buildFrontMatter(item templateBundleItem) string {
+	tags := item.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	variables := item.Variables
+	if variables == nil {
+		variables = []TemplateVariable{}
+	}
+	nameJSON, _ := json.Marshal(item.Name)
+	tagsJSON, _ := json.Marshal(tags)
+	createdAtJSON, _ := json.Marshal(item.CreatedAt.UTC().Format(time.RFC3339))
+	variablesJSON, _ := json.Marshal(variables)
+
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	sb.WriteString("name: " + string(nameJSON) + "\n")
+	sb.WriteString("tags: " + string(tagsJSON) + "\n")
+	sb.WriteString("created_at: " + string(createdAtJSON) + "\n")
+	sb.WriteString("variables: " + string(variablesJSON) + "\n")
+	sb.WriteString("---\n")
+	return sb.String()
+}
+
+// parseFrontMatterDoc reads the "key: json-value" lines of a front matter
+// block back into a bundle item.
+func parseFrontMatterDoc(lines []string) (templateBundleItem, error) {
+	var item templateBundleItem
+	for _, line := range lines {
+		key, raw, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		var err error
+		switch key {
+		case "name":
+			err = json.Unmarshal([]byte(raw), &item.Name)
+		case "tags":
+			err = json.Unmarshal([]byte(raw), &item.Tags)
+		case "created_at":
+			var s string
+			if err = json.Unmarshal([]byte(raw), &s); err == nil {
+				item.CreatedAt, err = time.Parse(time.RFC3339, s)
+			}
+		case "variables":
+			err = json.Unmarshal([]byte(raw), &item.Variables)
+		}
+		if err != nil {
+			return item, fmt.Errorf("invalid %q field: %w", key, err)
+		}
+	}
+	if item.Name == "" {
+		return item, fmt.Errorf("front matter missing name")
+	}
+	return item, nil
+}
+
+// parseFrontMatterFile parses a single "---\n...\n---\ncontent" document, as
+// produced for each file in a ZIP export.
+func parseFrontMatterFile(raw string) (templateBundleItem, error) {
+	if !strings.HasPrefix(raw, "---\n") {
+		return templateBundleItem{}, fmt.Errorf("missing front matter")
+	}
+	rest := raw[len("---\n"):]
+	end := strings.Index(rest, "\n---\n")
+	if end == -1 {
+		return templateBundleItem{}, fmt.Errorf("unterminated front matter")
+	}
+	item, err := parseFrontMatterDoc(strings.Split(rest[:end], "\n"))
+	if err != nil {
+		return item, err
+	}
+	item.Content = strings.TrimSuffix(rest[end+len("\n---\n"):], "\n")
+	return item, nil
+}
+
+// importTemplates reads a bundle in the requested format and upserts each
+// template into the caller's account, processing one item at a time rather
+// than buffering the whole bundle. With dry_run=true, nothing is written and
+// the response describes what would have changed.
+func importTemplates(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromContext(r)
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	summary := importSummary{DryRun: dryRun, Created: []string{}, Updated: []string{}, Unchanged: []string{}}
+	process := func(item templateBundleItem) error {
+		status, err := upsertImportedTemplate(userID, item, dryRun)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case "created":
+			summary.Created = append(summary.Created, item.Name)
+		case "updated":
+			summary.Updated = append(summary.Updated, item.Name)
+		case "unchanged":
+			summary.Unchanged = append(summary.Unchanged, item.Name)
+		}
+		return nil
+	}
+
+	var err error
+	switch format {
+	case "json":
+		err = importJSON(r.Body, process)
+	case "yaml":
+		err = importYAML(r.Body, process)
+	case "zip":
+		err = importZIP(r.Body, process)
+	default:
+		http.Error(w, "Unsupported format (use json, yaml, or zip)", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to import templates: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// importJSON decodes a JSON array one element at a time via the streaming
+// token API, rather than decoding the whole body into a slice.
+func importJSON(body io.Reader, process func(templateBundleItem) error) error {
+	dec := json.NewDecoder(body)
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		var item templateBundleItem
+		if err := dec.Decode(&item); err != nil {
+			return err
+		}
+		if err := process(item); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// importYAML reads a stream of front-matter Markdown documents, emitting
+// each completed document to process as soon as its closing line is seen.
+func importYAML(body io.Reader, process func(templateBundleItem) error) error {
+	const (
+		stateBetween = iota
+		stateFrontMatter
+		stateBody
+	)
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	state := stateBetween
+	var frontMatterLines, bodyLines []string
+
+	emit := func() error {
+		item, err := parseFrontMatterDoc(frontMatterLines)
+		if err != nil {
+			return err
+		}
+		item.Content = strings.Join(bodyLines, "\n")
+		return process(item)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch state {
+		case stateBetween:
+			if line == "---" {
+				frontMatterLines = nil
+				state = stateFrontMatter
+			}
+		case stateFrontMatter:
+			if line == "---" {
+				bodyLines = nil
+				state = stateBody
+			} else {
+				frontMatterLines = append(frontMatterLines, line)
+			}
+		case stateBody:
+			if line == "---" {
+				if err := emit(); err != nil {
+					return err
+				}
+				frontMatterLines = nil
+				state = stateFrontMatter
+			} else {
+				bodyLines = append(bodyLines, line)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if state == stateBody {
+		return emit()
+	}
+	return nil
+}
+
+// importZIP reads each Markdown entry of a ZIP archive. The ZIP format
+// requires random access to read its central directory, so (unlike the JSON
+// and YAML formats) the uploaded archive is read into memory in full; the
+// database writes it drives are still applied one template at a time.
+func importZIP(body io.Reader, process func(templateBundleItem) error) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !strings.HasSuffix(f.Name, ".md") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		raw, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		item, err := parseFrontMatterFile(string(raw))
+		if err != nil {
+			return err
+		}
+		if err := process(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertImportedTemplate creates or updates item in userID's account by
+// name. The variable schema is always resynced to match item, but tags are
+// only resynced when item.Tags is non-nil, since a nil slice means the
+// bundle's "tags" field was omitted rather than explicitly emptied. It
+// returns "created", "updated", or "unchanged" (by content); in dry-run
+// mode the database is left untouched and the status describes what would
+// have happened.
+func upsertImportedTemplate(userID int, item templateBundleItem, dryRun bool) (string, error) {
+	var existingID int
+	var existingContent string
+	err := db.QueryRow("SELECT id, content FROM templates WHERE user_id = ? AND name = ?", userID, item.Name).Scan(&existingID, &existingContent)
+
+	switch {
+	case err == sql.ErrNoRows:
+		if dryRun {
+			return "created", nil
+		}
+		variables := deriveVariableSchema(item.Content, item.Variables)
+		variablesJSON, err := json.Marshal(variables)
+		if err != nil {
+			return "", err
+		}
+		result, err := db.Exec(
+			"INSERT INTO templates (user_id, name, content, template_variables) VALUES (?, ?, ?, ?)",
+			userID, item.Name, item.Content, string(variablesJSON),
+		)
+		if err != nil {
+			return "", err
+		}
+		newID, _ := result.LastInsertId()
+		if err := recordTemplateVersion(int(newID), item.Name, item.Content, "", "Imported"); err != nil {
+			return "", err
+		}
+		if err := setTemplateTags(int(newID), item.Tags); err != nil {
+			return "", err
+		}
+		return "created", nil
+
+	case err != nil:
+		return "", err
+
+	case existingContent == item.Content:
+		// Content is identical, but the bundle's tags/variables still need
+		// to be synced — otherwise re-importing after only editing a
+		// template's tags silently drops them. A nil slice means the field
+		// was omitted from the bundle entirely (as opposed to an explicit
+		// empty list), so leave that side untouched rather than clearing it.
+		if dryRun {
+			return "unchanged", nil
+		}
+		if item.Variables != nil {
+			variables := deriveVariableSchema(item.Content, item.Variables)
+			variablesJSON, err := json.Marshal(variables)
+			if err != nil {
+				return "", err
+			}
+			if _, err := db.Exec("UPDATE templates SET template_variables = ? WHERE id = ?", string(variablesJSON), existingID); err != nil {
+				return "", err
+			}
+		}
+		if item.Tags != nil {
+			if err := setTemplateTags(existingID, item.Tags); err != nil {
+				return "", err
+			}
+		}
+		return "unchanged", nil
+
+	default:
+		if dryRun {
+			return "updated", nil
+		}
+		variables := deriveVariableSchema(item.Content, item.Variables)
+		variablesJSON, err := json.Marshal(variables)
+		if err != nil {
+			return "", err
+		}
+		if _, err := db.Exec(
+			"UPDATE templates SET content = ?, template_variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+			item.Content, string(variablesJSON), existingID,
+		); err != nil {
+			return "", err
+		}
+		if err := recordTemplateVersion(existingID, item.Name, item.Content, "", "Imported"); err != nil {
+			return "", err
+		}
+		// As in the unchanged-content branch above, a nil Tags means the
+		// bundle omitted the field entirely, so leave existing tags alone
+		// rather than wiping them.
+		if item.Tags != nil {
+			if err := setTemplateTags(existingID, item.Tags); err != nil {
+				return "", err
+			}
+		}
+		return "updated", nil
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// setupImportTestDB points the package-level db at a fresh in-memory SQLite
+// database with just the tables upsertImportedTemplate touches.
+func setupImportTestDB(t *testing.T) {
+	t.Helper()
+
+	var err error
+	db, err = sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ddl := []string{
+		`CREATE TABLE templates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL DEFAULT 0,
+			workspace_id INTEGER,
+			name TEXT NOT NULL,
+			content TEXT NOT NULL,
+			template_variables TEXT NOT NULL DEFAULT '[]',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE UNIQUE INDEX idx_templates_user_name ON templates (user_id, name)`,
+		`CREATE TABLE tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE
+		)`,
+		`CREATE TABLE template_tags (
+			template_id INTEGER NOT NULL,
+			tag_id INTEGER NOT NULL,
+			PRIMARY KEY (template_id, tag_id)
+		)`,
+		`CREATE TABLE template_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			template_id INTEGER NOT NULL,
+			version INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			content TEXT NOT NULL,
+			author TEXT,
+			change_note TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(template_id, version)
+		)`,
+	}
+	for _, stmt := range ddl {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup schema: %v", err)
+		}
+	}
+}
+
+// Regression test for a bundle re-import that changes a template's content
+// but omits its "tags" field (as every export of an untagged template, or
+// any hand-edited bundle, does): existing tags must survive, not be wiped.
+func TestUpsertImportedTemplateKeepsTagsWhenBundleOmitsThemOnChangedContent(t *testing.T) {
+	setupImportTestDB(t)
+
+	const userID = 1
+	result, err := db.Exec("INSERT INTO templates (user_id, name, content) VALUES (?, 'greeting', 'Hello {{name}}')", userID)
+	if err != nil {
+		t.Fatalf("seed template: %v", err)
+	}
+	templateID, _ := result.LastInsertId()
+	if err := setTemplateTags(int(templateID), []string{"important"}); err != nil {
+		t.Fatalf("seed tags: %v", err)
+	}
+
+	item := templateBundleItem{Name: "greeting", Content: "Hi {{name}}"} // Tags omitted -> nil
+
+	status, err := upsertImportedTemplate(userID, item, false)
+	if err != nil {
+		t.Fatalf("upsertImportedTemplate: %v", err)
+	}
+	if status != "updated" {
+		t.Fatalf("status = %q, want %q", status, "updated")
+	}
+
+	tags, err := templateTags(int(templateID))
+	if err != nil {
+		t.Fatalf("templateTags: %v", err)
+	}
+	if len(tags) != 1 || tags[0] != "important" {
+		t.Fatalf("tags = %v, want [important] to be preserved", tags)
+	}
+}
@@ -5,22 +5,46 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
-	_ "github.com/mattn/go-sqlite3"
+	_ "github.com/mattn/go-sqlite3" // build with -tags "sqlite_fts5" for FTS5 support
 )
 
 type Template struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	WorkspaceID *int      `json:"workspace_id,omitempty"`
+	Name        string    `json:"name"`
+	Content     string    `json:"content"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Author and ChangeNote are write-only: they're attached to the
+	// revision row created for this request rather than stored on the
+	// template itself.
+	Author     string `json:"author,omitempty"`
+	ChangeNote string `json:"change_note,omitempty"`
+
+	// Variables is derived from the `{{var_name}}` placeholders in Content
+	// on every save; callers may additionally describe type/required/
+	// default/description per variable and it will be preserved.
+	Variables []TemplateVariable `json:"variables,omitempty"`
+
+	// Tags is populated on read from the template_tags join table.
+	Tags []string `json:"tags,omitempty"`
 }
 
 var db *sql.DB
 
+// ftsEnabled reports whether the sqlite3 driver was built with FTS5 support
+// (go-sqlite3 requires the "sqlite_fts5" build tag for that). When it's not
+// available, GET /templates falls back to a LIKE-based search instead of
+// the server failing to start.
+var ftsEnabled = true
+
 func main() {
 	var err error
 	// Open SQLite database file inside data folder
@@ -34,7 +58,8 @@ func main() {
 	createTable := `
 	CREATE TABLE IF NOT EXISTS templates (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL UNIQUE,
+		user_id INTEGER NOT NULL DEFAULT 0,
+		name TEXT NOT NULL,
 		content TEXT NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
@@ -45,6 +70,175 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Migrate databases created before templates were scoped to a user.
+	_, err = db.Exec("ALTER TABLE templates ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0")
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		log.Fatal(err)
+	}
+
+	// Template names only need to be unique within a single user's templates.
+	_, err = db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_templates_user_name ON templates (user_id, name)")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Migrate databases created before templates could be shared via a workspace.
+	_, err = db.Exec("ALTER TABLE templates ADD COLUMN workspace_id INTEGER")
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		log.Fatal(err)
+	}
+
+	// Migrate databases created before templates carried a variable schema.
+	_, err = db.Exec("ALTER TABLE templates ADD COLUMN template_variables TEXT NOT NULL DEFAULT '[]'")
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		log.Fatal(err)
+	}
+
+	createUsersTable := `
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT NOT NULL UNIQUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	_, err = db.Exec(createUsersTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createTokensTable := `
+	CREATE TABLE IF NOT EXISTS tokens (
+		token TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	_, err = db.Exec(createTokensTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createSessionsTable := `
+	CREATE TABLE IF NOT EXISTS sessions (
+		token TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	_, err = db.Exec(createSessionsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createWorkspacesTable := `
+	CREATE TABLE IF NOT EXISTS workspaces (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		owner_user_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	_, err = db.Exec(createWorkspacesTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createWorkspaceMembersTable := `
+	CREATE TABLE IF NOT EXISTS workspace_members (
+		workspace_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (workspace_id, user_id)
+	);
+	`
+	_, err = db.Exec(createWorkspaceMembersTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createTagsTable := `
+	CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE
+	);
+	`
+	_, err = db.Exec(createTagsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createTemplateTagsTable := `
+	CREATE TABLE IF NOT EXISTS template_tags (
+		template_id INTEGER NOT NULL,
+		tag_id INTEGER NOT NULL,
+		PRIMARY KEY (template_id, tag_id)
+	);
+	`
+	_, err = db.Exec(createTemplateTagsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Full text search over template name/content, kept in sync with the
+	// templates table via triggers. The sqlite3 driver needs to be built
+	// with the "sqlite_fts5" tag for this to work; rather than bricking the
+	// whole server when it wasn't, fall back to LIKE-based search.
+	_, err = db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS templates_fts USING fts5(name, content, content='templates', content_rowid='id')")
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module") {
+			log.Println("warning: sqlite3 driver built without fts5 support, GET /templates?q= will fall back to LIKE search:", err)
+			ftsEnabled = false
+		} else {
+			log.Fatal(err)
+		}
+	}
+	if ftsEnabled {
+		_, err = db.Exec(`
+		CREATE TRIGGER IF NOT EXISTS templates_fts_ai AFTER INSERT ON templates BEGIN
+			INSERT INTO templates_fts(rowid, name, content) VALUES (new.id, new.name, new.content);
+		END;
+		`)
+		if err != nil {
+			log.Fatal(err)
+		}
+		_, err = db.Exec(`
+		CREATE TRIGGER IF NOT EXISTS templates_fts_ad AFTER DELETE ON templates BEGIN
+			INSERT INTO templates_fts(templates_fts, rowid, name, content) VALUES ('delete', old.id, old.name, old.content);
+		END;
+		`)
+		if err != nil {
+			log.Fatal(err)
+		}
+		_, err = db.Exec(`
+		CREATE TRIGGER IF NOT EXISTS templates_fts_au AFTER UPDATE ON templates BEGIN
+			INSERT INTO templates_fts(templates_fts, rowid, name, content) VALUES ('delete', old.id, old.name, old.content);
+			INSERT INTO templates_fts(rowid, name, content) VALUES (new.id, new.name, new.content);
+		END;
+		`)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	createVersionsTable := `
+	CREATE TABLE IF NOT EXISTS template_versions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		template_id INTEGER NOT NULL,
+		version INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		content TEXT NOT NULL,
+		author TEXT,
+		change_note TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(template_id, version)
+	);
+	`
+	_, err = db.Exec(createVersionsTable)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Set up router and endpoints
 	r := mux.NewRouter()
 
@@ -52,11 +246,37 @@ func main() {
 	r.Use(corsMiddleware)
 
 	// 2. Define routes and include "OPTIONS" in Methods
-	r.HandleFunc("/templates", getTemplates).Methods("GET", "OPTIONS")
-	r.HandleFunc("/templates", createTemplate).Methods("POST", "OPTIONS")
-	r.HandleFunc("/templates/{id}", getTemplate).Methods("GET", "OPTIONS")
-	r.HandleFunc("/templates/{id}", updateTemplate).Methods("PUT", "OPTIONS")
-	r.HandleFunc("/templates/{id}", deleteTemplate).Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/users", createUser).Methods("POST", "OPTIONS")
+	r.HandleFunc("/auth/login", authLogin).Methods("GET", "OPTIONS")
+	r.HandleFunc("/auth/callback", authCallback).Methods("GET", "OPTIONS")
+	r.HandleFunc("/auth/logout", authLogout).Methods("POST", "OPTIONS")
+
+	// Workspaces let templates be shared beyond a single owner; membership
+	// changes require the same bearer-token auth as /templates*.
+	workspaces := r.PathPrefix("/workspaces").Subrouter()
+	workspaces.Use(authMiddleware)
+	workspaces.HandleFunc("", createWorkspace).Methods("POST", "OPTIONS")
+	workspaces.HandleFunc("/{id}/members", addWorkspaceMember).Methods("POST", "OPTIONS")
+
+	// All /templates* routes require a valid API token.
+	templates := r.PathPrefix("/templates").Subrouter()
+	templates.Use(authMiddleware)
+	templates.HandleFunc("", getTemplates).Methods("GET", "OPTIONS")
+	templates.HandleFunc("", createTemplate).Methods("POST", "OPTIONS")
+	// Registered ahead of /{id} so "export"/"import" aren't swallowed by it.
+	templates.HandleFunc("/export", exportTemplates).Methods("GET", "OPTIONS")
+	templates.HandleFunc("/import", importTemplates).Methods("POST", "OPTIONS")
+	templates.HandleFunc("/{id}", getTemplate).Methods("GET", "OPTIONS")
+	templates.HandleFunc("/{id}", updateTemplate).Methods("PUT", "OPTIONS")
+	templates.HandleFunc("/{id}", deleteTemplate).Methods("DELETE", "OPTIONS")
+	templates.HandleFunc("/{id}/versions", getTemplateVersions).Methods("GET", "OPTIONS")
+	templates.HandleFunc("/{id}/versions/{version}", getTemplateVersion).Methods("GET", "OPTIONS")
+	templates.HandleFunc("/{id}/rollback/{version}", rollbackTemplate).Methods("POST", "OPTIONS")
+	templates.HandleFunc("/{id}/diff", diffTemplateVersions).Methods("GET", "OPTIONS")
+	templates.HandleFunc("/{id}/schema", getTemplateSchema).Methods("GET", "OPTIONS")
+	templates.HandleFunc("/{id}/render", renderTemplateHandler).Methods("POST", "OPTIONS")
+	templates.HandleFunc("/{id}/tags", addTemplateTag).Methods("POST", "OPTIONS")
+	templates.HandleFunc("/{id}/tags/{tag}", removeTemplateTag).Methods("DELETE", "OPTIONS")
 
 	log.Println("Server running on https://api.prompts.faizghanchi.com")
 	log.Fatal(http.ListenAndServe(":7979", r))
@@ -80,48 +300,53 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func getTemplates(w http.ResponseWriter, r *http.Request) {
-	rows, err := db.Query("SELECT id, name, content, created_at, updated_at FROM templates")
-	if err != nil {
-		http.Error(w, "Failed to query templates", http.StatusInternalServerError)
+func createTemplate(w http.ResponseWriter, r *http.Request) {
+	var t Template
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
-	defer rows.Close()
+	t.UserID = userIDFromContext(r)
 
-	templates := []Template{}
-	for rows.Next() {
-		var t Template
-		if err := rows.Scan(&t.ID, &t.Name, &t.Content, &t.CreatedAt, &t.UpdatedAt); err != nil {
-			http.Error(w, "Error scanning template", http.StatusInternalServerError)
+	if t.WorkspaceID != nil {
+		role, err := workspaceRole(*t.WorkspaceID, t.UserID)
+		if err != nil {
+			http.Error(w, "Failed to check workspace membership", http.StatusInternalServerError)
+			return
+		}
+		if role != "owner" && role != "editor" {
+			http.Error(w, "Not a member of that workspace", http.StatusForbidden)
 			return
 		}
-		templates = append(templates, t)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(templates)
-}
 
-func createTemplate(w http.ResponseWriter, r *http.Request) {
-	var t Template
-	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	t.Variables = deriveVariableSchema(t.Content, t.Variables)
+	variablesJSON, err := json.Marshal(t.Variables)
+	if err != nil {
+		http.Error(w, "Failed to encode variable schema", http.StatusInternalServerError)
 		return
 	}
 
-	stmt, err := db.Prepare("INSERT INTO templates (name, content) VALUES (?, ?)")
+	stmt, err := db.Prepare("INSERT INTO templates (user_id, workspace_id, name, content, template_variables) VALUES (?, ?, ?, ?, ?)")
 	if err != nil {
 		http.Error(w, "Failed to prepare statement", http.StatusInternalServerError)
 		return
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(t.Name, t.Content)
+	result, err := stmt.Exec(t.UserID, t.WorkspaceID, t.Name, t.Content, string(variablesJSON))
 	if err != nil {
 		http.Error(w, "Template name might be duplicate", http.StatusBadRequest)
 		return
 	}
 	lastID, _ := result.LastInsertId()
 	t.ID = int(lastID)
+
+	if err := recordTemplateVersion(t.ID, t.Name, t.Content, t.Author, t.ChangeNote); err != nil {
+		http.Error(w, "Failed to record template version", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(t)
 }
@@ -129,13 +354,43 @@ func createTemplate(w http.ResponseWriter, r *http.Request) {
 func getTemplate(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
-	row := db.QueryRow("SELECT id, name, content, created_at, updated_at FROM templates WHERE id = ?", id)
+	userID := userIDFromContext(r)
+
+	templateID, err := strconv.Atoi(id)
+	if err != nil {
+		http.Error(w, "Invalid template id", http.StatusBadRequest)
+		return
+	}
+	canRead, _, err := templateAccess(templateID, userID)
+	if err != nil {
+		http.Error(w, "Failed to check template access", http.StatusInternalServerError)
+		return
+	}
+	if !canRead {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	row := db.QueryRow("SELECT id, user_id, workspace_id, name, content, template_variables, created_at, updated_at FROM templates WHERE id = ?", id)
 
 	var t Template
-	if err := row.Scan(&t.ID, &t.Name, &t.Content, &t.CreatedAt, &t.UpdatedAt); err != nil {
+	var workspaceID sql.NullInt64
+	var variablesJSON string
+	if err := row.Scan(&t.ID, &t.UserID, &workspaceID, &t.Name, &t.Content, &variablesJSON, &t.CreatedAt, &t.UpdatedAt); err != nil {
 		http.Error(w, "Template not found", http.StatusNotFound)
 		return
 	}
+	t.WorkspaceID = nullInt64ToPtr(workspaceID)
+	if err := json.Unmarshal([]byte(variablesJSON), &t.Variables); err != nil {
+		http.Error(w, "Failed to read variable schema", http.StatusInternalServerError)
+		return
+	}
+	tags, err := templateTags(t.ID)
+	if err != nil {
+		http.Error(w, "Failed to load tags", http.StatusInternalServerError)
+		return
+	}
+	t.Tags = tags
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(t)
 }
@@ -143,41 +398,85 @@ func getTemplate(w http.ResponseWriter, r *http.Request) {
 func updateTemplate(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
+	userID := userIDFromContext(r)
 	var t Template
 	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
 
-	stmt, err := db.Prepare("UPDATE templates SET name = ?, content = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?")
+	templateID, err := strconv.Atoi(id)
 	if err != nil {
-		http.Error(w, "Failed to prepare statement", http.StatusInternalServerError)
+		http.Error(w, "Invalid template id", http.StatusBadRequest)
+		return
+	}
+	_, canWrite, err := templateAccess(templateID, userID)
+	if err != nil {
+		http.Error(w, "Failed to check template access", http.StatusInternalServerError)
+		return
+	}
+	if !canWrite {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	t.Variables = deriveVariableSchema(t.Content, t.Variables)
+	variablesJSON, err := json.Marshal(t.Variables)
+	if err != nil {
+		http.Error(w, "Failed to encode variable schema", http.StatusInternalServerError)
 		return
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(t.Name, t.Content, id)
+	_, err = db.Exec(
+		"UPDATE templates SET name = ?, content = ?, template_variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		t.Name, t.Content, string(variablesJSON), id,
+	)
 	if err != nil {
 		http.Error(w, "Failed to update template", http.StatusInternalServerError)
 		return
 	}
+
+	if err := recordTemplateVersion(templateID, t.Name, t.Content, t.Author, t.ChangeNote); err != nil {
+		http.Error(w, "Failed to record template version", http.StatusInternalServerError)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func deleteTemplate(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
-	stmt, err := db.Prepare("DELETE FROM templates WHERE id = ?")
+	userID := userIDFromContext(r)
+
+	templateID, err := strconv.Atoi(id)
 	if err != nil {
-		http.Error(w, "Failed to prepare statement", http.StatusInternalServerError)
+		http.Error(w, "Invalid template id", http.StatusBadRequest)
 		return
 	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(id)
+	_, canWrite, err := templateAccess(templateID, userID)
 	if err != nil {
+		http.Error(w, "Failed to check template access", http.StatusInternalServerError)
+		return
+	}
+	if !canWrite {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	if _, err := db.Exec("DELETE FROM templates WHERE id = ?", id); err != nil {
 		http.Error(w, "Failed to delete template", http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// nullInt64ToPtr converts a nullable SQL column into the *int used by the
+// JSON-facing Template struct.
+func nullInt64ToPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// oauthConfig describes the OIDC relying-party flow against whichever
+// provider is configured via environment variables (Google, GitHub, ...).
+var oauthConfig = oauth2.Config{
+	ClientID:     os.Getenv("OAUTH_CLIENT_ID"),
+	ClientSecret: os.Getenv("OAUTH_CLIENT_SECRET"),
+	RedirectURL:  os.Getenv("OAUTH_REDIRECT_URL"),
+	Scopes:       []string{"openid", "email"},
+	Endpoint: oauth2.Endpoint{
+		AuthURL:  os.Getenv("OAUTH_AUTH_URL"),
+		TokenURL: os.Getenv("OAUTH_TOKEN_URL"),
+	},
+}
+
+// oauthUserInfoURL returns the caller's email when hit with a bearer token
+// from the provider, e.g. Google's "https://www.googleapis.com/oauth2/v2/userinfo".
+var oauthUserInfoURL = os.Getenv("OAUTH_USERINFO_URL")
+
+const oauthStateCookie = "oauth_state"
+
+// authLogin redirects the browser to the provider's consent screen,
+// stashing an anti-CSRF state value in a short-lived cookie.
+func authLogin(w http.ResponseWriter, r *http.Request) {
+	state, err := generateToken()
+	if err != nil {
+		http.Error(w, "Failed to start login", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    state,
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   300,
+	})
+	http.Redirect(w, r, oauthConfig.AuthCodeURL(state), http.StatusFound)
+}
+
+// authCallback exchanges the provider's code for a token, resolves the
+// caller's email, upserts a user, and issues a session token.
+func authCallback(w http.ResponseWriter, r *http.Request) {
+	stateCookie, err := r.Cookie(oauthStateCookie)
+	if err != nil || r.URL.Query().Get("state") != stateCookie.Value {
+		http.Error(w, "Invalid OAuth state", http.StatusBadRequest)
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	token, err := oauthConfig.Exchange(r.Context(), code)
+	if err != nil {
+		http.Error(w, "Failed to exchange code", http.StatusBadGateway)
+		return
+	}
+
+	email, err := fetchOAuthEmail(r, token.AccessToken)
+	if err != nil || email == "" {
+		http.Error(w, "Failed to resolve account email", http.StatusBadGateway)
+		return
+	}
+
+	userID, err := findOrCreateUserByEmail(email)
+	if err != nil {
+		http.Error(w, "Failed to resolve user", http.StatusInternalServerError)
+		return
+	}
+
+	sessionToken, err := generateToken()
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	if _, err := db.Exec("INSERT INTO sessions (token, user_id) VALUES (?, ?)", sessionToken, userID); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id": userID,
+		"email":   email,
+		"token":   sessionToken,
+	})
+}
+
+// authLogout deletes the session behind the caller's bearer token.
+func authLogout(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+		return
+	}
+	if _, err := db.Exec("DELETE FROM sessions WHERE token = ?", token); err != nil {
+		http.Error(w, "Failed to log out", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func fetchOAuthEmail(r *http.Request, accessToken string) (string, error) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, oauthUserInfoURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var info struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+	return info.Email, nil
+}
+
+func findOrCreateUserByEmail(email string) (int, error) {
+	var userID int
+	row := db.QueryRow("SELECT id FROM users WHERE email = ?", email)
+	err := row.Scan(&userID)
+	if err == nil {
+		return userID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	result, err := db.Exec("INSERT INTO users (email) VALUES (?)", email)
+	if err != nil {
+		return 0, err
+	}
+	lastID, err := result.LastInsertId()
+	return int(lastID), err
+}
+
+// resolveUserIDFromToken accepts either a long-lived API token or a
+// session token minted by the OAuth callback.
+func resolveUserIDFromToken(token string) (int, error) {
+	var userID int
+	row := db.QueryRow("SELECT user_id FROM tokens WHERE token = ?", token)
+	if err := row.Scan(&userID); err == nil {
+		return userID, nil
+	} else if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	row = db.QueryRow("SELECT user_id FROM sessions WHERE token = ?", token)
+	if err := row.Scan(&userID); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
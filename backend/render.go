@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"text/template"
+
+	"github.com/gorilla/mux"
+)
+
+// TemplateVariable describes one `{{var_name}}` placeholder found in a
+// template's content, plus the typed schema used to validate renders.
+type TemplateVariable struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"` // "string", "number", or "boolean"
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// extractVariableNames returns the distinct `{{var_name}}` placeholders in
+// content, in order of first appearance.
+func extractVariableNames(content string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, match := range variablePattern.FindAllStringSubmatch(content, -1) {
+		name := match[1]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// deriveVariableSchema walks the placeholders actually present in content,
+// filling in type/required/default/description from provided where the
+// caller described them and defaulting to a required string otherwise.
+func deriveVariableSchema(content string, provided []TemplateVariable) []TemplateVariable {
+	byName := map[string]TemplateVariable{}
+	for _, v := range provided {
+		byName[v.Name] = v
+	}
+
+	variables := []TemplateVariable{}
+	for _, name := range extractVariableNames(content) {
+		if v, ok := byName[name]; ok {
+			v.Name = name
+			variables = append(variables, v)
+			continue
+		}
+		variables = append(variables, TemplateVariable{Name: name, Type: "string", Required: true})
+	}
+	return variables
+}
+
+// zeroValueForType returns the empty value to substitute for an optional
+// variable that was left out of a render call.
+func zeroValueForType(t string) interface{} {
+	switch t {
+	case "number":
+		return 0
+	case "boolean":
+		return false
+	default:
+		return ""
+	}
+}
+
+// renderTemplate substitutes vars into content's `{{var_name}}` placeholders
+// using the text/template engine.
+func renderTemplate(content string, vars map[string]interface{}) (string, error) {
+	src := variablePattern.ReplaceAllString(content, "{{.$1}}")
+	tmpl, err := template.New("render").Option("missingkey=zero").Parse(src)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// validateRenderVars checks vars against schema, returning one message per
+// missing required variable or type mismatch.
+func validateRenderVars(schema []TemplateVariable, vars map[string]interface{}) []string {
+	var errs []string
+	for _, v := range schema {
+		value, present := vars[v.Name]
+		if !present {
+			if v.Required && v.Default == nil {
+				errs = append(errs, fmt.Sprintf("missing required variable %q", v.Name))
+			}
+			continue
+		}
+		switch v.Type {
+		case "number":
+			switch value.(type) {
+			case float64, json.Number:
+			default:
+				errs = append(errs, fmt.Sprintf("variable %q must be a number", v.Name))
+			}
+		case "boolean":
+			if _, ok := value.(bool); !ok {
+				errs = append(errs, fmt.Sprintf("variable %q must be a boolean", v.Name))
+			}
+		}
+	}
+	return errs
+}
+
+func getTemplateSchema(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !canAccessTemplate(w, r, id, false) {
+		return
+	}
+
+	var variablesJSON string
+	row := db.QueryRow("SELECT template_variables FROM templates WHERE id = ?", id)
+	if err := row.Scan(&variablesJSON); err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	var variables []TemplateVariable
+	if err := json.Unmarshal([]byte(variablesJSON), &variables); err != nil {
+		http.Error(w, "Failed to read variable schema", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(variables)
+}
+
+func renderTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !canAccessTemplate(w, r, id, false) {
+		return
+	}
+
+	var input struct {
+		Vars map[string]interface{} `json:"vars"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	var content, variablesJSON string
+	row := db.QueryRow("SELECT content, template_variables FROM templates WHERE id = ?", id)
+	if err := row.Scan(&content, &variablesJSON); err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	var schema []TemplateVariable
+	if err := json.Unmarshal([]byte(variablesJSON), &schema); err != nil {
+		http.Error(w, "Failed to read variable schema", http.StatusInternalServerError)
+		return
+	}
+
+	if input.Vars == nil {
+		input.Vars = map[string]interface{}{}
+	}
+	for _, v := range schema {
+		if _, present := input.Vars[v.Name]; !present && v.Default != nil {
+			input.Vars[v.Name] = v.Default
+		}
+	}
+
+	if errs := validateRenderVars(schema, input.Vars); len(errs) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": errs})
+		return
+	}
+
+	// Any schema variable still missing at this point is optional and has
+	// no default (required-but-missing would have failed validation above),
+	// so fill it with its type's zero value. Otherwise text/template's
+	// missingkey=zero renders it as the literal string "<no value>".
+	for _, v := range schema {
+		if _, present := input.Vars[v.Name]; !present {
+			input.Vars[v.Name] = zeroValueForType(v.Type)
+		}
+	}
+
+	prompt, err := renderTemplate(content, input.Vars)
+	if err != nil {
+		http.Error(w, "Failed to render template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"prompt": prompt})
+}
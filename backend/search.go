@@ -0,0 +1,172 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTemplatesPageSize = 20
+	maxTemplatesPageSize     = 100
+)
+
+// templatesPage is the response shape for GET /templates: a page of items
+// plus an opaque cursor for fetching the next page.
+type templatesPage struct {
+	Items      []Template `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// cursorTimeFormat matches SQLite's strftime('%Y-%m-%dT%H:%M:%fZ', ...), so
+// the cursor's timestamp compares correctly against t.updated_at once both
+// sides are normalized to this format (see getTemplates).
+const cursorTimeFormat = "2006-01-02T15:04:05.000"
+
+// encodeCursor packs the keyset pagination position into an opaque token.
+func encodeCursor(updatedAt time.Time, id int) string {
+	raw := fmt.Sprintf("%s|%d", updatedAt.UTC().Format(cursorTimeFormat)+"Z", id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor reverses encodeCursor.
+func decodeCursor(cursor string) (string, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, err
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("malformed cursor")
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, err
+	}
+	return parts[0], id, nil
+}
+
+// getTemplates lists templates visible to the caller, with optional full
+// text search (?q=), tag filtering (?tag=), and keyset pagination
+// (?limit=&cursor=).
+func getTemplates(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromContext(r)
+	query := r.URL.Query()
+
+	limit := defaultTemplatesPageSize
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+		if limit > maxTemplatesPageSize {
+			limit = maxTemplatesPageSize
+		}
+	}
+
+	var cursorUpdatedAt string
+	var cursorID int
+	if raw := query.Get("cursor"); raw != "" {
+		var err error
+		cursorUpdatedAt, cursorID, err = decodeCursor(raw)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var sqlQuery strings.Builder
+	args := []interface{}{}
+
+	sqlQuery.WriteString(`SELECT t.id, t.user_id, t.workspace_id, t.name, t.content, t.template_variables, t.created_at, t.updated_at
+		FROM templates t`)
+
+	if q := query.Get("q"); q != "" && ftsEnabled {
+		sqlQuery.WriteString(" JOIN templates_fts ON templates_fts.rowid = t.id")
+	}
+	if tag := query.Get("tag"); tag != "" {
+		sqlQuery.WriteString(" JOIN template_tags ON template_tags.template_id = t.id JOIN tags ON tags.id = template_tags.tag_id")
+	}
+
+	sqlQuery.WriteString(" WHERE (t.user_id = ? OR t.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = ?))")
+	args = append(args, userID, userID)
+
+	if q := query.Get("q"); q != "" {
+		if ftsEnabled {
+			sqlQuery.WriteString(" AND templates_fts MATCH ?")
+			args = append(args, q)
+		} else {
+			sqlQuery.WriteString(" AND (t.name LIKE ? OR t.content LIKE ?)")
+			like := "%" + q + "%"
+			args = append(args, like, like)
+		}
+	}
+	if tag := query.Get("tag"); tag != "" {
+		sqlQuery.WriteString(" AND tags.name = ?")
+		args = append(args, tag)
+	}
+	if cursorUpdatedAt != "" {
+		// t.updated_at is stored as "YYYY-MM-DD HH:MM:SS" (SQLite's
+		// CURRENT_TIMESTAMP default), which doesn't sort correctly against
+		// the cursor's RFC3339-ish format by plain string comparison;
+		// normalize it to the cursor's format before comparing.
+		sqlQuery.WriteString(" AND (strftime('%Y-%m-%dT%H:%M:%fZ', t.updated_at), t.id) < (?, ?)")
+		args = append(args, cursorUpdatedAt, cursorID)
+	}
+
+	sqlQuery.WriteString(" ORDER BY t.updated_at DESC, t.id DESC LIMIT ?")
+	args = append(args, limit+1)
+
+	rows, err := db.Query(sqlQuery.String(), args...)
+	if err != nil {
+		http.Error(w, "Failed to query templates", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	items := []Template{}
+	for rows.Next() {
+		var t Template
+		var workspaceID sql.NullInt64
+		var variablesJSON string
+		if err := rows.Scan(&t.ID, &t.UserID, &workspaceID, &t.Name, &t.Content, &variablesJSON, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			http.Error(w, "Error scanning template", http.StatusInternalServerError)
+			return
+		}
+		t.WorkspaceID = nullInt64ToPtr(workspaceID)
+		if err := json.Unmarshal([]byte(variablesJSON), &t.Variables); err != nil {
+			http.Error(w, "Failed to read variable schema", http.StatusInternalServerError)
+			return
+		}
+		items = append(items, t)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Failed to query templates", http.StatusInternalServerError)
+		return
+	}
+
+	page := templatesPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[len(page.Items)-1]
+		page.NextCursor = encodeCursor(last.UpdatedAt, last.ID)
+	}
+	for i := range page.Items {
+		tags, err := templateTags(page.Items[i].ID)
+		if err != nil {
+			http.Error(w, "Failed to load tags", http.StatusInternalServerError)
+			return
+		}
+		page.Items[i].Tags = tags
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// templateTags returns the tag names attached to templateID, sorted.
+func templateTags(templateID int) ([]string, error) {
+	rows, err := db.Query(
+		"SELECT tags.name FROM template_tags JOIN tags ON tags.id = template_tags.tag_id WHERE template_tags.template_id = ? ORDER BY tags.name",
+		templateID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tags = append(tags, name)
+	}
+	return tags, rows.Err()
+}
+
+// setTemplateTags replaces templateID's entire tag set with tags, creating
+// any tags that don't already exist. Used by import, which receives a
+// template's full tag list at once rather than one tag at a time.
+func setTemplateTags(templateID int, tags []string) error {
+	if _, err := db.Exec("DELETE FROM template_tags WHERE template_id = ?", templateID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := db.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", tag); err != nil {
+			return err
+		}
+		var tagID int
+		if err := db.QueryRow("SELECT id FROM tags WHERE name = ?", tag).Scan(&tagID); err != nil {
+			return err
+		}
+		if _, err := db.Exec("INSERT OR IGNORE INTO template_tags (template_id, tag_id) VALUES (?, ?)", templateID, tagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTemplateTag attaches a tag to a template, creating the tag if needed.
+func addTemplateTag(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !canAccessTemplate(w, r, id, true) {
+		return
+	}
+
+	var input struct {
+		Tag string `json:"tag"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Tag == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := db.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", input.Tag); err != nil {
+		http.Error(w, "Failed to create tag", http.StatusInternalServerError)
+		return
+	}
+	var tagID int
+	if err := db.QueryRow("SELECT id FROM tags WHERE name = ?", input.Tag).Scan(&tagID); err != nil {
+		http.Error(w, "Failed to load tag", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := db.Exec("INSERT OR IGNORE INTO template_tags (template_id, tag_id) VALUES (?, ?)", id, tagID); err != nil {
+		http.Error(w, "Failed to tag template", http.StatusInternalServerError)
+		return
+	}
+
+	templateID, err := strconv.Atoi(id)
+	if err != nil {
+		http.Error(w, "Invalid template id", http.StatusBadRequest)
+		return
+	}
+	tags, err := templateTags(templateID)
+	if err != nil {
+		http.Error(w, "Failed to load tags", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"tags": tags})
+}
+
+// removeTemplateTag detaches a tag from a template.
+func removeTemplateTag(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+	tag := params["tag"]
+	if !canAccessTemplate(w, r, id, true) {
+		return
+	}
+
+	_, err := db.Exec(
+		"DELETE FROM template_tags WHERE template_id = ? AND tag_id = (SELECT id FROM tags WHERE name = ?)",
+		id, tag,
+	)
+	if err != nil {
+		http.Error(w, "Failed to remove tag", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TemplateVersion is an immutable snapshot of a template taken on every
+// create and update, so changes can be audited, diffed, and rolled back.
+type TemplateVersion struct {
+	ID         int       `json:"id"`
+	TemplateID int       `json:"template_id"`
+	Version    int       `json:"version"`
+	Name       string    `json:"name"`
+	Content    string    `json:"content"`
+	Author     string    `json:"author,omitempty"`
+	ChangeNote string    `json:"change_note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// recordTemplateVersion writes the next revision row for templateID,
+// numbering it one past the current highest version.
+func recordTemplateVersion(templateID int, name, content, author, changeNote string) error {
+	var next int
+	row := db.QueryRow("SELECT COALESCE(MAX(version), 0) + 1 FROM template_versions WHERE template_id = ?", templateID)
+	if err := row.Scan(&next); err != nil {
+		return err
+	}
+	_, err := db.Exec(
+		"INSERT INTO template_versions (template_id, version, name, content, author, change_note) VALUES (?, ?, ?, ?, ?, ?)",
+		templateID, next, name, content, author, changeNote,
+	)
+	return err
+}
+
+// canAccessTemplate writes a 404 and returns false unless the authenticated
+// caller has the required access to id, so version routes never leak
+// another user's (or workspace's) data.
+func canAccessTemplate(w http.ResponseWriter, r *http.Request, id string, requireWrite bool) bool {
+	templateID, err := strconv.Atoi(id)
+	if err != nil {
+		http.Error(w, "Invalid template id", http.StatusBadRequest)
+		return false
+	}
+	canRead, canWrite, err := templateAccess(templateID, userIDFromContext(r))
+	if err != nil {
+		http.Error(w, "Failed to check template access", http.StatusInternalServerError)
+		return false
+	}
+	allowed := canRead
+	if requireWrite {
+		allowed = canWrite
+	}
+	if !allowed {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+func getTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !canAccessTemplate(w, r, id, false) {
+		return
+	}
+	rows, err := db.Query(
+		"SELECT id, template_id, version, name, content, author, change_note, created_at FROM template_versions WHERE template_id = ? ORDER BY version DESC",
+		id,
+	)
+	if err != nil {
+		http.Error(w, "Failed to query versions", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	versions := []TemplateVersion{}
+	for rows.Next() {
+		var v TemplateVersion
+		var author, changeNote sql.NullString
+		if err := rows.Scan(&v.ID, &v.TemplateID, &v.Version, &v.Name, &v.Content, &author, &changeNote, &v.CreatedAt); err != nil {
+			http.Error(w, "Error scanning version", http.StatusInternalServerError)
+			return
+		}
+		v.Author = author.String
+		v.ChangeNote = changeNote.String
+		versions = append(versions, v)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+func getTemplateVersion(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+	n := params["version"]
+	if !canAccessTemplate(w, r, id, false) {
+		return
+	}
+
+	var v TemplateVersion
+	var author, changeNote sql.NullString
+	row := db.QueryRow(
+		"SELECT id, template_id, version, name, content, author, change_note, created_at FROM template_versions WHERE template_id = ? AND version = ?",
+		id, n,
+	)
+	if err := row.Scan(&v.ID, &v.TemplateID, &v.Version, &v.Name, &v.Content, &author, &changeNote, &v.CreatedAt); err != nil {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+	v.Author = author.String
+	v.ChangeNote = changeNote.String
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func rollbackTemplate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+	n := params["version"]
+	if !canAccessTemplate(w, r, id, true) {
+		return
+	}
+
+	var name, content string
+	row := db.QueryRow("SELECT name, content FROM template_versions WHERE template_id = ? AND version = ?", id, n)
+	if err := row.Scan(&name, &content); err != nil {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+
+	templateID, err := strconv.Atoi(id)
+	if err != nil {
+		http.Error(w, "Invalid template id", http.StatusBadRequest)
+		return
+	}
+
+	// The restored content's variable schema is rebuilt from scratch, since
+	// older per-variable type/required/default metadata isn't versioned.
+	variablesJSON, err := json.Marshal(deriveVariableSchema(content, nil))
+	if err != nil {
+		http.Error(w, "Failed to encode variable schema", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = db.Exec(
+		"UPDATE templates SET name = ?, content = ?, template_variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		name, content, string(variablesJSON), id,
+	)
+	if err != nil {
+		http.Error(w, "Failed to roll back template", http.StatusInternalServerError)
+		return
+	}
+
+	if err := recordTemplateVersion(templateID, name, content, "", "Rollback to version "+n); err != nil {
+		http.Error(w, "Failed to record rollback version", http.StatusInternalServerError)
+		return
+	}
+
+	var t Template
+	var workspaceID sql.NullInt64
+	var storedVariablesJSON string
+	row = db.QueryRow("SELECT id, user_id, workspace_id, name, content, template_variables, created_at, updated_at FROM templates WHERE id = ?", id)
+	if err := row.Scan(&t.ID, &t.UserID, &workspaceID, &t.Name, &t.Content, &storedVariablesJSON, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		http.Error(w, "Failed to load rolled back template", http.StatusInternalServerError)
+		return
+	}
+	t.WorkspaceID = nullInt64ToPtr(workspaceID)
+	if err := json.Unmarshal([]byte(storedVariablesJSON), &t.Variables); err != nil {
+		http.Error(w, "Failed to read variable schema", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+func diffTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !canAccessTemplate(w, r, id, false) {
+		return
+	}
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		http.Error(w, "Both from and to query params are required", http.StatusBadRequest)
+		return
+	}
+
+	var fromContent, toContent string
+	row := db.QueryRow("SELECT content FROM template_versions WHERE template_id = ? AND version = ?", id, from)
+	if err := row.Scan(&fromContent); err != nil {
+		http.Error(w, "from version not found", http.StatusNotFound)
+		return
+	}
+	row = db.QueryRow("SELECT content FROM template_versions WHERE template_id = ? AND version = ?", id, to)
+	if err := row.Scan(&toContent); err != nil {
+		http.Error(w, "to version not found", http.StatusNotFound)
+		return
+	}
+
+	diff := unifiedDiff("version "+from, "version "+to, fromContent, toContent)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"from": from,
+		"to":   to,
+		"diff": diff,
+	})
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Workspace lets a template be shared with a set of users instead of
+// belonging to a single owner.
+type Workspace struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	OwnerUserID int       `json:"owner_user_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+var validWorkspaceRoles = map[string]bool{"owner": true, "editor": true, "viewer": true}
+
+// createWorkspace creates a workspace and makes the caller its owner.
+func createWorkspace(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Name == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	userID := userIDFromContext(r)
+
+	// The workspace and its owner membership must be created together —
+	// otherwise a failure partway through leaves an orphaned workspace that
+	// templateAccess/workspaceRole can never resolve as writable by anyone.
+	tx, err := db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to create workspace", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO workspaces (name, owner_user_id) VALUES (?, ?)", input.Name, userID)
+	if err != nil {
+		http.Error(w, "Failed to create workspace", http.StatusInternalServerError)
+		return
+	}
+	workspaceID, _ := result.LastInsertId()
+
+	if _, err := tx.Exec("INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?, ?, 'owner')", workspaceID, userID); err != nil {
+		http.Error(w, "Failed to add owner membership", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to create workspace", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Workspace{ID: int(workspaceID), Name: input.Name, OwnerUserID: userID})
+}
+
+// addWorkspaceMember shares the workspace with another user, requiring
+// the caller to already be its owner.
+func addWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid workspace id", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Email == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if !validWorkspaceRoles[input.Role] {
+		http.Error(w, "Role must be one of owner, editor, viewer", http.StatusBadRequest)
+		return
+	}
+
+	callerRole, err := workspaceRole(workspaceID, userIDFromContext(r))
+	if err != nil {
+		http.Error(w, "Failed to check workspace membership", http.StatusInternalServerError)
+		return
+	}
+	if callerRole != "owner" {
+		http.Error(w, "Only the workspace owner can add members", http.StatusForbidden)
+		return
+	}
+
+	var memberUserID int
+	row := db.QueryRow("SELECT id FROM users WHERE email = ?", input.Email)
+	if err := row.Scan(&memberUserID); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	_, err = db.Exec(
+		"INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?, ?, ?) ON CONFLICT(workspace_id, user_id) DO UPDATE SET role = excluded.role",
+		workspaceID, memberUserID, input.Role,
+	)
+	if err != nil {
+		http.Error(w, "Failed to add member", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// workspaceRole returns the caller's role in a workspace, or "" if they're
+// not a member.
+func workspaceRole(workspaceID, userID int) (string, error) {
+	var role string
+	row := db.QueryRow("SELECT role FROM workspace_members WHERE workspace_id = ? AND user_id = ?", workspaceID, userID)
+	if err := row.Scan(&role); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+// templateAccess reports whether userID may read and/or write templateID,
+// accounting for direct ownership as well as workspace membership.
+func templateAccess(templateID, userID int) (canRead, canWrite bool, err error) {
+	var ownerUserID int
+	var workspaceID sql.NullInt64
+	row := db.QueryRow("SELECT user_id, workspace_id FROM templates WHERE id = ?", templateID)
+	if err := row.Scan(&ownerUserID, &workspaceID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	if ownerUserID == userID {
+		return true, true, nil
+	}
+	if !workspaceID.Valid {
+		return false, false, nil
+	}
+
+	role, err := workspaceRole(int(workspaceID.Int64), userID)
+	if err != nil {
+		return false, false, err
+	}
+	if role == "" {
+		return false, false, nil
+	}
+	return true, role == "owner" || role == "editor", nil
+}